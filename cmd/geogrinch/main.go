@@ -1,32 +1,310 @@
 package main
 
 import (
+	"flag"
+	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/alesr/geogrinch/internal/dataset"
 )
 
-const datasetFilePath string = "dataset/dataset.csv"
+const usage = `geogrinch <command> [flags]
+
+Commands:
+  load      load a dataset and print the parsed samples
+  variance  load a dataset and print per-group variances
+  ftest     load a dataset and print the mine/background F-distribution
+  analyze   load a dataset and print Mahalanobis outliers and a PCA
+  export    load a dataset and print every stage of the analysis
+
+Run 'geogrinch <command> -h' for flags available to a command.
+`
 
 func main() {
-	file, err := os.Open(datasetFilePath)
+	if len(os.Args) < 2 {
+		log.Fatal(usage)
+	}
+
+	cmd := os.Args[1]
+	args := os.Args[2:]
+
+	var err error
+	switch cmd {
+	case "load":
+		err = runLoad(args)
+	case "variance":
+		err = runVariance(args)
+	case "ftest":
+		err = runFTest(args)
+	case "analyze":
+		err = runAnalyze(args)
+	case "export":
+		err = runExport(args)
+	case "-h", "--help", "help":
+		fmt.Print(usage)
+		return
+	default:
+		log.Fatalf("unknown command %q\n\n%s", cmd, usage)
+	}
+
+	if err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// commonFlags are accepted by every subcommand: where to read the dataset
+// from and how to parse and render it.
+type commonFlags struct {
+	input       string
+	delimiter   string
+	groupCol    int
+	elements    string
+	elementCols string
+	outputFmt   string
+	precision   int
+	alpha       float64
+	exportPath  string
+}
+
+func bindCommonFlags(fs *flag.FlagSet, f *commonFlags) {
+	fs.StringVar(&f.input, "input", "dataset/dataset.csv", "path to the dataset CSV file")
+	fs.StringVar(&f.delimiter, "delimiter", ";", "field delimiter used by the input CSV")
+	fs.IntVar(&f.groupCol, "group-col", 3, "0-indexed column holding the sample group (mine/background)")
+	fs.StringVar(&f.elements, "elements", "pb,as,sb,v", "comma-separated element names, in the same order as --element-cols")
+	fs.StringVar(&f.elementCols, "element-cols", "4,5,6,7", "comma-separated 0-indexed columns, one per --elements entry")
+	fs.StringVar(&f.outputFmt, "output-format", "table", "output format: table, json, csv or tsv")
+	fs.IntVar(&f.precision, "precision", 2, "number of decimal places in rendered output")
+	fs.Float64Var(&f.alpha, "alpha", 0.05, "significance level used by the F-test and the outlier cutoff")
+	fs.StringVar(&f.exportPath, "export", "", "write PCA sample scores as CSV to this file (analyze and export commands)")
+}
+
+func (f commonFlags) loadConfig() (dataset.LoadConfig, error) {
+	if len(f.delimiter) != 1 {
+		return dataset.LoadConfig{}, fmt.Errorf("delimiter must be a single character, got %q", f.delimiter)
+	}
+
+	elements := parseElements(f.elements)
+
+	elementCols, err := parseElementCols(f.elementCols)
+	if err != nil {
+		return dataset.LoadConfig{}, err
+	}
+
+	if len(elements) != len(elementCols) {
+		return dataset.LoadConfig{}, fmt.Errorf("--elements lists %d names but --element-cols lists %d columns", len(elements), len(elementCols))
+	}
+
+	cfg := dataset.DefaultLoadConfig()
+	cfg.Delimiter = rune(f.delimiter[0])
+	cfg.GroupCol = f.groupCol
+	cfg.Elements = elements
+	cfg.ElementCols = elementCols
+	return cfg, nil
+}
+
+func parseElements(raw string) []string {
+	parts := strings.Split(raw, ",")
+	for i, p := range parts {
+		parts[i] = strings.TrimSpace(p)
+	}
+	return parts
+}
+
+func parseElementCols(raw string) ([]int, error) {
+	parts := strings.Split(raw, ",")
+
+	cols := make([]int, len(parts))
+	for i, p := range parts {
+		col, err := strconv.Atoi(strings.TrimSpace(p))
+		if err != nil {
+			return nil, fmt.Errorf("could not parse element-cols: %s", err)
+		}
+		cols[i] = col
+	}
+	return cols, nil
+}
+
+func (f commonFlags) renderer() (dataset.Renderer, error) {
+	return dataset.NewRenderer(dataset.OutputFormat(f.outputFmt), f.precision)
+}
+
+func loadDataset(f commonFlags) (dataset.Dataset, error) {
+	cfg, err := f.loadConfig()
+	if err != nil {
+		return dataset.Dataset{}, err
+	}
+
+	file, err := os.Open(f.input)
+	if err != nil {
+		return dataset.Dataset{}, fmt.Errorf("failed to open dataset file: %s", err)
+	}
+
+	ds, err := dataset.Load(file, cfg)
+	if err != nil {
+		return dataset.Dataset{}, fmt.Errorf("failed to load dataset: %s", err)
+	}
+	return ds, nil
+}
+
+func runLoad(args []string) error {
+	f := &commonFlags{}
+	fs := flag.NewFlagSet("load", flag.ExitOnError)
+	bindCommonFlags(fs, f)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ds, err := loadDataset(*f)
+	if err != nil {
+		return err
+	}
+
+	r, err := f.renderer()
+	if err != nil {
+		return err
+	}
+	return ds.PrintDataset(r)
+}
+
+func runVariance(args []string) error {
+	f := &commonFlags{}
+	fs := flag.NewFlagSet("variance", flag.ExitOnError)
+	bindCommonFlags(fs, f)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ds, err := loadDataset(*f)
+	if err != nil {
+		return err
+	}
+
+	if err := ds.CalculateVariances(); err != nil {
+		return fmt.Errorf("failed to calculate variances: %s", err)
+	}
+
+	r, err := f.renderer()
+	if err != nil {
+		return err
+	}
+	return ds.PrintVariances(r)
+}
+
+func runFTest(args []string) error {
+	f := &commonFlags{}
+	fs := flag.NewFlagSet("ftest", flag.ExitOnError)
+	bindCommonFlags(fs, f)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ds, err := loadDataset(*f)
+	if err != nil {
+		return err
+	}
+
+	if err := ds.CalculateVariances(); err != nil {
+		return fmt.Errorf("failed to calculate variances: %s", err)
+	}
+	if err := ds.CalculateFTests(f.alpha); err != nil {
+		return fmt.Errorf("failed to calculate F-tests: %s", err)
+	}
+
+	r, err := f.renderer()
+	if err != nil {
+		return err
+	}
+	return ds.PrintFTest(r)
+}
+
+func runAnalyze(args []string) error {
+	f := &commonFlags{}
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	bindCommonFlags(fs, f)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ds, err := loadDataset(*f)
+	if err != nil {
+		return err
+	}
+
+	if err := ds.Analyze(f.alpha); err != nil {
+		return fmt.Errorf("failed to analyze dataset: %s", err)
+	}
+
+	r, err := f.renderer()
 	if err != nil {
-		log.Fatalln("failed to open dataset file:", err)
+		return err
+	}
+
+	if err := ds.PrintOutliers(r); err != nil {
+		return err
+	}
+	if err := ds.PrintPCA(r); err != nil {
+		return err
+	}
+
+	if f.exportPath != "" {
+		if err := ds.ExportPCAScores(f.exportPath, f.precision); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runExport(args []string) error {
+	f := &commonFlags{}
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	bindCommonFlags(fs, f)
+	if err := fs.Parse(args); err != nil {
+		return err
 	}
 
-	ds, err := dataset.Load(file)
+	ds, err := loadDataset(*f)
 	if err != nil {
-		log.Fatalln("failed to load dataset:", err)
+		return err
 	}
 
 	if err := ds.CalculateVariances(); err != nil {
-		log.Fatalln("failed to calculate variances:", err)
+		return fmt.Errorf("failed to calculate variances: %s", err)
+	}
+	if err := ds.CalculateFTests(f.alpha); err != nil {
+		return fmt.Errorf("failed to calculate F-tests: %s", err)
+	}
+	if err := ds.Analyze(f.alpha); err != nil {
+		return fmt.Errorf("failed to analyze dataset: %s", err)
 	}
 
-	ds.CalculateFDistributions()
+	r, err := f.renderer()
+	if err != nil {
+		return err
+	}
 
-	ds.PrintDataset()
-	ds.PrintVariances()
-	ds.PrintFDistributions()
+	if err := ds.PrintDataset(r); err != nil {
+		return err
+	}
+	if err := ds.PrintVariances(r); err != nil {
+		return err
+	}
+	if err := ds.PrintFTest(r); err != nil {
+		return err
+	}
+	if err := ds.PrintOutliers(r); err != nil {
+		return err
+	}
+	if err := ds.PrintPCA(r); err != nil {
+		return err
+	}
+
+	if f.exportPath != "" {
+		if err := ds.ExportPCAScores(f.exportPath, f.precision); err != nil {
+			return err
+		}
+	}
+	return nil
 }
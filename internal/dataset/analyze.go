@@ -0,0 +1,202 @@
+package dataset
+
+import "fmt"
+
+type (
+	// outlierResult is the Mahalanobis-distance outlier test for a single
+	// sample against its own group's mean and covariance.
+	outlierResult struct {
+		code      string
+		group     groupType
+		distance  float64
+		cutoff    float64
+		isOutlier bool
+	}
+
+	// pcaLoading is a single element's contribution to the first two
+	// principal components.
+	pcaLoading struct {
+		element  string
+		pc1, pc2 float64
+	}
+
+	// pcaScore is a single sample's position on the first two principal
+	// components.
+	pcaScore struct {
+		code     string
+		group    groupType
+		pc1, pc2 float64
+	}
+
+	pcaResult struct {
+		loadings               []pcaLoading
+		explainedVarianceRatio []float64
+		scores                 []pcaScore
+	}
+)
+
+// vector returns s as a point in element-space, in Dataset.elements order.
+func (s sample) vector() []float64 {
+	return s.values
+}
+
+// Analyze treats every sample as a vector in element-space, centers and
+// scales it, and runs (a) per-group Mahalanobis-distance outlier detection
+// against an adjusted cutoff (see outlierCutoff) at the given significance
+// level, and (b) a PCA over every sample. Results are stored on the
+// Dataset for PrintOutliers, PrintPCA and ExportPCAScores.
+func (ds *Dataset) Analyze(alpha float64) error {
+	groups := groupTypes
+
+	var codes []string
+	var sampleGroups []groupType
+	var raw [][]float64
+
+	for _, g := range groups {
+		for _, s := range ds.samples[g] {
+			codes = append(codes, s.code)
+			sampleGroups = append(sampleGroups, g)
+			raw = append(raw, s.vector())
+		}
+	}
+
+	p := len(ds.elements)
+	minSamples := p + 1
+	if len(raw) < minSamples {
+		return fmt.Errorf("need at least %d samples to analyze, got %d", minSamples, len(raw))
+	}
+
+	scaled, _, _ := standardize(raw)
+
+	outliers, err := detectOutliers(groups, codes, sampleGroups, scaled, alpha, p)
+	if err != nil {
+		return err
+	}
+	ds.outliers = outliers
+	ds.pca = runPCA(ds.elements, codes, sampleGroups, scaled)
+
+	return nil
+}
+
+// detectOutliers runs a per-group Mahalanobis-distance test: for each
+// group, every sample's squared distance from its own group's mean is
+// compared against outlierCutoff, an adjusted cutoff derived from that same
+// group's sample size.
+func detectOutliers(groups []groupType, codes []string, sampleGroups []groupType, scaled [][]float64, alpha float64, p int) ([]outlierResult, error) {
+	byGroup := map[groupType][][]float64{}
+	indicesByGroup := map[groupType][]int{}
+	for i, g := range sampleGroups {
+		byGroup[g] = append(byGroup[g], scaled[i])
+		indicesByGroup[g] = append(indicesByGroup[g], i)
+	}
+
+	results := make([]outlierResult, len(scaled))
+
+	for _, g := range groups {
+		data := byGroup[g]
+		if len(data) < p+1 {
+			return nil, fmt.Errorf("group %q needs at least %d samples for outlier detection, got %d", g, p+1, len(data))
+		}
+
+		mean := meanVector(data)
+		cov := covarianceMatrix(data, mean)
+
+		covInv, err := invertMatrix(cov)
+		if err != nil {
+			return nil, fmt.Errorf("could not invert covariance matrix for group %q: %s", g, err)
+		}
+
+		cutoff := outlierCutoff(alpha, len(data), p)
+
+		for _, idx := range indicesByGroup[g] {
+			d := mahalanobisDistanceSquared(scaled[idx], mean, covInv)
+			results[idx] = outlierResult{
+				code:      codes[idx],
+				group:     g,
+				distance:  d,
+				cutoff:    cutoff,
+				isOutlier: d > cutoff,
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// outlierCutoff returns the threshold c such that P(D² > c) = alpha for the
+// squared Mahalanobis distance of one of n samples, in p dimensions, from
+// the mean and covariance estimated from those same n samples. Because the
+// sample being tested also contributes to that mean and covariance, D² is
+// bounded above by (n-1)²/n and never reaches the plain chi-square cutoff
+// at p degrees of freedom for realistic group sizes. Scaled by n/(n-1)²,
+// D² instead follows a Beta(p/2, (n-p-1)/2) distribution (Gnanadesikan &
+// Kettenring, 1972), so the cutoff is built from that distribution's
+// quantile instead. At the smallest group size detectOutliers allows
+// (n = p+1), that Beta distribution degenerates to a point mass at its
+// upper bound, with every D² landing exactly on it up to floating-point
+// noise, so the cutoff is nudged a hair above the bound to keep that noise
+// from spuriously flagging a sample as an outlier.
+func outlierCutoff(alpha float64, n, p int) float64 {
+	bound := float64((n-1)*(n-1)) / float64(n)
+
+	b := float64(n-p-1) / 2
+	if b <= 0 {
+		return bound * (1 + 1e-9)
+	}
+
+	q := betaQuantile(1-alpha, float64(p)/2, b)
+	return bound * q
+}
+
+// runPCA performs a PCA over every standardized sample, keeping the first
+// two principal components.
+func runPCA(elements []string, codes []string, sampleGroups []groupType, scaled [][]float64) pcaResult {
+	p := len(elements)
+
+	mean := meanVector(scaled) // ~0, standardize() already centered the data
+	cov := covarianceMatrix(scaled, mean)
+
+	eigenvalues, eigenvectors := jacobiEigen(cov)
+
+	var totalVariance float64
+	for _, ev := range eigenvalues {
+		totalVariance += ev
+	}
+
+	explainedVarianceRatio := make([]float64, len(eigenvalues))
+	for i, ev := range eigenvalues {
+		if totalVariance != 0 {
+			explainedVarianceRatio[i] = ev / totalVariance
+		}
+	}
+
+	loadings := make([]pcaLoading, p)
+	for i, name := range elements {
+		loadings[i] = pcaLoading{
+			element: name,
+			pc1:     eigenvectors[i][0],
+			pc2:     eigenvectors[i][1],
+		}
+	}
+
+	scores := make([]pcaScore, len(scaled))
+	for i, row := range scaled {
+		var pc1, pc2 float64
+		for j, v := range row {
+			pc1 += v * eigenvectors[j][0]
+			pc2 += v * eigenvectors[j][1]
+		}
+		scores[i] = pcaScore{
+			code:  codes[i],
+			group: sampleGroups[i],
+			pc1:   pc1,
+			pc2:   pc2,
+		}
+	}
+
+	return pcaResult{
+		loadings:               loadings,
+		explainedVarianceRatio: explainedVarianceRatio,
+		scores:                 scores,
+	}
+}
@@ -5,68 +5,91 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"os"
 	"strconv"
 
-	"github.com/jedib0t/go-pretty/table"
 	"github.com/montanaflynn/stats"
 )
 
 const (
-	rowLen int = 8
-
 	groupTypeMine       groupType = "mine"
 	groupTypeBackground groupType = "background"
 )
 
-var tableStyle table.Style = table.Style{
-	Name:    "StyleRounded",
-	Box:     table.StyleBoxRounded,
-	Color:   table.ColorOptionsDefault,
-	Format:  table.FormatOptionsDefault,
-	Options: table.OptionsDefault,
-	Title:   table.TitleOptionsDefault,
-}
+// groupTypes fixes the iteration order over the mine/background groups, so
+// rendering a Dataset produces the same row order on every run instead of
+// Go's randomized map order.
+var groupTypes = []groupType{groupTypeMine, groupTypeBackground}
 
 type (
 	groupType string
 
 	sample struct {
-		group groupType
-		code  string
-		xUtm  string
-		yUtm  string
-		pbPpm float64
-		asPpm float64
-		sbPpm float64
-		vPpm  float64
+		group  groupType
+		code   string
+		xUtm   string
+		yUtm   string
+		values []float64 // aligned with Dataset.elements
 	}
 
 	variances struct {
-		pb, as, sb, v float64
+		values []float64 // aligned with Dataset.elements
 	}
 
-	fDistributions struct {
-		pb, as, sb, v float64
+	Dataset struct {
+		elements  []string
+		samples   map[groupType][]sample
+		variances map[groupType]variances
+		fTests    fTestResults
+		outliers  []outlierResult
+		pca       pcaResult
 	}
 
-	dataset struct {
-		samples        map[groupType][]sample
-		variances      map[groupType]variances
-		fDistributions fDistributions
+	// LoadConfig describes how to parse a CSV into a dataset: which
+	// delimiter separates fields, which column holds each value, and the
+	// element panel to read. Elements and ElementCols must have the same
+	// length, pairing each element name with the column it's read from.
+	LoadConfig struct {
+		Delimiter   rune
+		CodeCol     int
+		XCol        int
+		YCol        int
+		GroupCol    int
+		Elements    []string
+		ElementCols []int
 	}
 )
 
-func Load(f io.ReadCloser) (dataset, error) {
+// DefaultLoadConfig returns the column mapping matching the bundled
+// dataset.csv: code, x_utm, y_utm, group, pb_ppm, as_ppm, sb_ppm, v_ppm.
+func DefaultLoadConfig() LoadConfig {
+	return LoadConfig{
+		Delimiter:   ';',
+		CodeCol:     0,
+		XCol:        1,
+		YCol:        2,
+		GroupCol:    3,
+		Elements:    []string{"pb", "as", "sb", "v"},
+		ElementCols: []int{4, 5, 6, 7},
+	}
+}
+
+func Load(f io.ReadCloser, cfg LoadConfig) (Dataset, error) {
 	defer func() {
 		if err := f.Close(); err != nil {
 			log.Println("could not close dataset file:", err)
 		}
 	}()
 
+	if len(cfg.Elements) != len(cfg.ElementCols) {
+		return Dataset{}, fmt.Errorf("LoadConfig.Elements has %d entries but ElementCols has %d", len(cfg.Elements), len(cfg.ElementCols))
+	}
+
 	csvReader := csv.NewReader(f)
-	csvReader.Comma = ';'
+	csvReader.Comma = cfg.Delimiter
 
-	ds := dataset{
+	ds := Dataset{
+		elements:  cfg.Elements,
 		samples:   map[groupType][]sample{},
 		variances: map[groupType]variances{},
 	}
@@ -77,10 +100,11 @@ func Load(f io.ReadCloser) (dataset, error) {
 			break
 		}
 		if err != nil {
-			log.Fatal(err)
+			log.Printf("could not read data row: %s", err)
+			continue
 		}
 
-		sample, err := newSample(rec)
+		sample, err := newSample(rec, cfg)
 		if err != nil {
 			log.Printf("could not parse data row '%+#v': %s", rec, err)
 			continue
@@ -91,18 +115,24 @@ func Load(f io.ReadCloser) (dataset, error) {
 	return ds, nil
 }
 
-func newSample(input []string) (sample, error) {
-	if len(input) != rowLen {
+func newSample(input []string, cfg LoadConfig) (sample, error) {
+	minLen := cfg.CodeCol
+	for _, col := range append([]int{cfg.XCol, cfg.YCol, cfg.GroupCol}, cfg.ElementCols...) {
+		if col > minLen {
+			minLen = col
+		}
+	}
+	if len(input) <= minLen {
 		return sample{}, fmt.Errorf("invalid row length: %d", len(input))
 	}
 
 	s := sample{
-		code: input[0],
-		xUtm: input[1],
-		yUtm: input[2],
+		code: input[cfg.CodeCol],
+		xUtm: input[cfg.XCol],
+		yUtm: input[cfg.YCol],
 	}
 
-	inputGroup := input[3]
+	inputGroup := input[cfg.GroupCol]
 
 	switch inputGroup {
 	case "mine":
@@ -113,61 +143,45 @@ func newSample(input []string) (sample, error) {
 		return sample{}, fmt.Errorf("invalid group: %s", inputGroup)
 	}
 
-	pbPPM, err := strconv.ParseFloat(input[4], 64)
-	if err != nil {
-		return sample{}, fmt.Errorf("could not parse pb_ppm to float64: %s", err)
-	}
-	s.pbPpm = pbPPM
-
-	asPPM, err := strconv.ParseFloat(input[5], 64)
-	if err != nil {
-		return sample{}, fmt.Errorf("could not parse as_ppm to float64: %s", err)
-	}
-	s.asPpm = asPPM
-
-	sbPPM, err := strconv.ParseFloat(input[6], 64)
-	if err != nil {
-		return sample{}, fmt.Errorf("could not parse sb_ppm to float64: %s", err)
-	}
-	s.sbPpm = sbPPM
-
-	vPPM, err := strconv.ParseFloat(input[7], 64)
-	if err != nil {
-		return sample{}, fmt.Errorf("could not parse v_ppm to float64: %s", err)
+	values := make([]float64, len(cfg.ElementCols))
+	for i, col := range cfg.ElementCols {
+		v, err := strconv.ParseFloat(input[col], 64)
+		if err != nil {
+			return sample{}, fmt.Errorf("could not parse %s to float64: %s", cfg.Elements[i], err)
+		}
+		values[i] = v
 	}
-	s.vPpm = vPPM
+	s.values = values
 
 	return s, nil
 }
 
-func (ds *dataset) CalculateVariances() error {
-	var pbMine, pbBg, asMine, asBg, sbMine, sbBg, vMine, vBg stats.Float64Data
+func (ds *Dataset) CalculateVariances() error {
+	columns := map[groupType][]stats.Float64Data{
+		groupTypeMine:       make([]stats.Float64Data, len(ds.elements)),
+		groupTypeBackground: make([]stats.Float64Data, len(ds.elements)),
+	}
 
 	for k, groupSamples := range ds.samples {
+		cols, ok := columns[k]
+		if !ok {
+			continue
+		}
 		for _, sample := range groupSamples {
-			switch k {
-			case "mine":
-				pbMine = append(pbMine, sample.pbPpm)
-				asMine = append(asMine, sample.asPpm)
-				sbMine = append(sbMine, sample.sbPpm)
-				vMine = append(vMine, sample.vPpm)
-			case "background":
-				pbBg = append(pbBg, sample.pbPpm)
-				asBg = append(asBg, sample.asPpm)
-				sbBg = append(sbBg, sample.sbPpm)
-				vBg = append(vBg, sample.vPpm)
+			for i, v := range sample.values {
+				cols[i] = append(cols[i], v)
 			}
 		}
 	}
 
-	mineVariances, err := calcGroupVariances(pbMine, asMine, sbMine, vMine)
+	mineVariances, err := calcGroupVariances(ds.elements, columns[groupTypeMine])
 	if err != nil {
 		return fmt.Errorf("could not calculate group variances for group 'mine': %s", err)
 	}
 
 	ds.variances[groupTypeMine] = mineVariances
 
-	bgVariances, err := calcGroupVariances(pbBg, asBg, sbBg, vBg)
+	bgVariances, err := calcGroupVariances(ds.elements, columns[groupTypeBackground])
 	if err != nil {
 		return fmt.Errorf("could not calculate group variances for group 'background': %s", err)
 	}
@@ -177,95 +191,83 @@ func (ds *dataset) CalculateVariances() error {
 	return nil
 }
 
-func calcGroupVariances(pb, as, sb, v stats.Float64Data) (variances, error) {
-	pbVari, err := stats.Variance(pb)
-	if err != nil {
-		return variances{}, fmt.Errorf("could not calculate variance for pb_ppm: %s", err)
+func calcGroupVariances(elements []string, columns []stats.Float64Data) (variances, error) {
+	values := make([]float64, len(elements))
+	for i, col := range columns {
+		v, err := stats.SampleVariance(col)
+		if err != nil {
+			return variances{}, fmt.Errorf("could not calculate variance for %s: %s", elements[i], err)
+		}
+		values[i] = v
 	}
+	return variances{values: values}, nil
+}
 
-	asVari, err := stats.Variance(as)
+// PrintDataset renders the loaded samples with r and writes the result to
+// stdout.
+func (ds *Dataset) PrintDataset(r Renderer) error {
+	out, err := r.RenderDataset(ds)
 	if err != nil {
-		return variances{}, fmt.Errorf("could not calculate variance for as_ppm: %s", err)
+		return fmt.Errorf("could not render dataset: %s", err)
 	}
+	fmt.Println(out)
+	return nil
+}
 
-	sbVari, err := stats.Variance(sb)
+// PrintVariances renders the per-group variances with r and writes the
+// result to stdout.
+func (ds *Dataset) PrintVariances(r Renderer) error {
+	out, err := r.RenderVariances(ds)
 	if err != nil {
-		return variances{}, fmt.Errorf("could not calculate variance for sb_ppm: %s", err)
+		return fmt.Errorf("could not render variances: %s", err)
 	}
+	fmt.Println(out)
+	return nil
+}
 
-	vVari, err := stats.Variance(v)
+// PrintFTest renders the per-element F-test results with r and writes the
+// result to stdout.
+func (ds *Dataset) PrintFTest(r Renderer) error {
+	out, err := r.RenderFTest(ds)
 	if err != nil {
-		return variances{}, fmt.Errorf("could not calculate variance for v_ppm: %s", err)
+		return fmt.Errorf("could not render F-test: %s", err)
 	}
-
-	return variances{
-		pb: pbVari,
-		as: asVari,
-		sb: sbVari,
-		v:  vVari,
-	}, nil
-}
-
-func (ds *dataset) CalculateFDistributions() {
-	ds.fDistributions.pb = ds.variances[groupTypeMine].pb / ds.variances[groupTypeBackground].pb
-	ds.fDistributions.as = ds.variances[groupTypeMine].as / ds.variances[groupTypeBackground].as
-	ds.fDistributions.sb = ds.variances[groupTypeMine].sb / ds.variances[groupTypeBackground].sb
-	ds.fDistributions.v = ds.variances[groupTypeMine].v / ds.variances[groupTypeBackground].v
+	fmt.Println(out)
+	return nil
 }
 
-func (ds *dataset) PrintDataset() {
-	tw := table.NewWriter()
-	tw.SetStyle(tableStyle)
-	tw.SetTitle("DATASET")
-	tw.AppendHeader(table.Row{"group", "sample", "x_utm", "y_utm", "pb_ppm", "as_ppm", "sb_ppm", "v_ppm"})
-
-	for _, samples := range ds.samples {
-		for _, sample := range samples {
-			tw.AppendRow(table.Row{
-				sample.group,
-				sample.code,
-				sample.xUtm,
-				sample.yUtm,
-				fmt.Sprintf("%.2f", sample.pbPpm),
-				fmt.Sprintf("%.2f", sample.asPpm),
-				fmt.Sprintf("%.2f", sample.sbPpm),
-				fmt.Sprintf("%.2f", sample.vPpm),
-			})
-		}
+// PrintOutliers renders the per-sample Mahalanobis outlier test computed by
+// Analyze with r and writes the result to stdout.
+func (ds *Dataset) PrintOutliers(r Renderer) error {
+	out, err := r.RenderOutliers(ds)
+	if err != nil {
+		return fmt.Errorf("could not render outliers: %s", err)
 	}
-
-	fmt.Println(tw.Render())
+	fmt.Println(out)
+	return nil
 }
 
-func (ds *dataset) PrintVariances() {
-	tw := table.NewWriter()
-	tw.SetStyle(tableStyle)
-	tw.SetTitle("VARIANCE")
-	tw.AppendHeader(table.Row{"group", "pb", "as", "sb", "v"})
-
-	for group, vari := range ds.variances {
-		tw.AppendRow(table.Row{
-			group,
-			fmt.Sprintf("%.3f", vari.pb),
-			fmt.Sprintf("%.3f", vari.as),
-			fmt.Sprintf("%.3f", vari.sb),
-			fmt.Sprintf("%.3f", vari.v),
-		})
+// PrintPCA renders the PCA computed by Analyze with r and writes the result
+// to stdout.
+func (ds *Dataset) PrintPCA(r Renderer) error {
+	out, err := r.RenderPCA(ds)
+	if err != nil {
+		return fmt.Errorf("could not render PCA: %s", err)
 	}
-	fmt.Println(tw.Render())
+	fmt.Println(out)
+	return nil
 }
 
-func (ds *dataset) PrintFDistributions() {
-	tw := table.NewWriter()
-	tw.SetStyle(tableStyle)
-	tw.SetTitle("F-DISTRIBUTION")
-	tw.AppendHeader(table.Row{"pb", "as", "sb", "v"})
-
-	tw.AppendRow(table.Row{
-		fmt.Sprintf("%.3f", ds.fDistributions.pb),
-		fmt.Sprintf("%.3f", ds.fDistributions.as),
-		fmt.Sprintf("%.3f", ds.fDistributions.sb),
-		fmt.Sprintf("%.3f", ds.fDistributions.v),
-	})
-	fmt.Println(tw.Render())
+// ExportPCAScores writes the per-sample PC1/PC2 scores computed by Analyze
+// to path as CSV, regardless of the renderer used elsewhere, so downstream
+// plotting tools get a stable format.
+func (ds *Dataset) ExportPCAScores(path string, precision int) error {
+	out, err := renderPCAScoresCSV(ds, precision, ',')
+	if err != nil {
+		return fmt.Errorf("could not render PCA scores: %s", err)
+	}
+	if err := os.WriteFile(path, []byte(out), 0o644); err != nil {
+		return fmt.Errorf("could not write PCA scores to %s: %s", path, err)
+	}
+	return nil
 }
@@ -0,0 +1,258 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	fixturesDir = "tests"
+	testAlpha   = 0.05
+
+	floatAtol = 1e-6
+	floatRtol = 1e-6
+)
+
+type expectedSample struct {
+	Code   string             `yaml:"code"`
+	XUtm   string             `yaml:"x_utm"`
+	YUtm   string             `yaml:"y_utm"`
+	Values map[string]float64 `yaml:"values"`
+}
+
+type expectedFTest struct {
+	N1       int     `yaml:"n1"`
+	N2       int     `yaml:"n2"`
+	DF1      int     `yaml:"df1"`
+	DF2      int     `yaml:"df2"`
+	F        float64 `yaml:"f"`
+	PValue   float64 `yaml:"p_value"`
+	Critical float64 `yaml:"critical"`
+	RejectH0 bool    `yaml:"reject_h0"`
+}
+
+// expected is the golden result for a tests/<dir> fixture: the samples
+// newSample should parse out of input.csv, the per-group variances
+// CalculateVariances should derive from them, and the per-element F-test
+// CalculateFTests should run at testAlpha. Per-element values are keyed by
+// name rather than baked into fixed fields, so fixtures aren't tied to any
+// particular element panel.
+type expected struct {
+	Samples struct {
+		Mine       []expectedSample `yaml:"mine"`
+		Background []expectedSample `yaml:"background"`
+	} `yaml:"samples"`
+	Variances struct {
+		Mine       map[string]float64 `yaml:"mine"`
+		Background map[string]float64 `yaml:"background"`
+	} `yaml:"variances"`
+	FTest map[string]expectedFTest `yaml:"ftest"`
+}
+
+// TestDataset walks internal/dataset/tests, running Load, CalculateVariances
+// and CalculateFTests on each fixture's input.csv and diffing the result
+// against its expected.yaml. Set TEST_ONLY=<dir> to run a single fixture.
+func TestDataset(t *testing.T) {
+	for _, dir := range fixtureDirs(t) {
+		dir := dir
+		t.Run(dir, func(t *testing.T) {
+			ds, exp := loadFixture(t, dir)
+			checkDataset(t, ds, exp)
+		})
+	}
+}
+
+// BenchmarkDataset reruns every fixture's Load + CalculateVariances +
+// CalculateFTests, so regressions in the parser or the numerics also show
+// up as a throughput change.
+func BenchmarkDataset(b *testing.B) {
+	dirs, err := fixtureDirNames()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	for _, dir := range dirs {
+		dir := dir
+		b.Run(dir, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := runFixture(dir); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func fixtureDirs(t *testing.T) []string {
+	t.Helper()
+
+	if only := os.Getenv("TEST_ONLY"); only != "" {
+		return []string{only}
+	}
+
+	dirs, err := fixtureDirNames()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dirs
+}
+
+func fixtureDirNames() ([]string, error) {
+	entries, err := os.ReadDir(fixturesDir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read fixtures dir: %s", err)
+	}
+
+	var dirs []string
+	for _, e := range entries {
+		if e.IsDir() {
+			dirs = append(dirs, e.Name())
+		}
+	}
+	return dirs, nil
+}
+
+// runFixture loads tests/<dir>/input.csv and runs the same pipeline the
+// ftest CLI subcommand does.
+func runFixture(dir string) (Dataset, error) {
+	f, err := os.Open(filepath.Join(fixturesDir, dir, "input.csv"))
+	if err != nil {
+		return Dataset{}, fmt.Errorf("could not open input.csv: %s", err)
+	}
+
+	ds, err := Load(f, DefaultLoadConfig())
+	if err != nil {
+		return Dataset{}, fmt.Errorf("Load returned an error: %s", err)
+	}
+	if err := ds.CalculateVariances(); err != nil {
+		return Dataset{}, fmt.Errorf("CalculateVariances returned an error: %s", err)
+	}
+	if err := ds.CalculateFTests(testAlpha); err != nil {
+		return Dataset{}, fmt.Errorf("CalculateFTests returned an error: %s", err)
+	}
+	return ds, nil
+}
+
+func loadFixture(t *testing.T, dir string) (Dataset, expected) {
+	t.Helper()
+
+	ds, err := runFixture(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	raw, err := os.ReadFile(filepath.Join(fixturesDir, dir, "expected.yaml"))
+	if err != nil {
+		t.Fatalf("could not read expected.yaml: %s", err)
+	}
+
+	var exp expected
+	if err := yaml.Unmarshal(raw, &exp); err != nil {
+		t.Fatalf("could not parse expected.yaml: %s", err)
+	}
+
+	return ds, exp
+}
+
+func checkDataset(t *testing.T, ds Dataset, exp expected) {
+	t.Helper()
+
+	checkSamples(t, ds.elements, "mine", ds.samples[groupTypeMine], exp.Samples.Mine)
+	checkSamples(t, ds.elements, "background", ds.samples[groupTypeBackground], exp.Samples.Background)
+
+	checkVariances(t, ds.elements, "mine", ds.variances[groupTypeMine], exp.Variances.Mine)
+	checkVariances(t, ds.elements, "background", ds.variances[groupTypeBackground], exp.Variances.Background)
+
+	for _, el := range ds.fTestElements() {
+		want, ok := exp.FTest[el.name]
+		if !ok {
+			t.Errorf("expected.yaml has no ftest entry for element %q", el.name)
+			continue
+		}
+		checkFTest(t, el.name, el.result, want)
+	}
+}
+
+func checkSamples(t *testing.T, elements []string, group string, got []sample, want []expectedSample) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("group %q: got %d samples, want %d", group, len(got), len(want))
+	}
+
+	for i, w := range want {
+		g := got[i]
+		label := fmt.Sprintf("group %q sample %d (%s)", group, i, w.Code)
+
+		if g.code != w.Code {
+			t.Errorf("%s: code = %q, want %q", label, g.code, w.Code)
+		}
+		if g.xUtm != w.XUtm {
+			t.Errorf("%s: x_utm = %q, want %q", label, g.xUtm, w.XUtm)
+		}
+		if g.yUtm != w.YUtm {
+			t.Errorf("%s: y_utm = %q, want %q", label, g.yUtm, w.YUtm)
+		}
+		for j, el := range elements {
+			wantVal, ok := w.Values[el]
+			if !ok {
+				t.Errorf("%s: expected.yaml has no value for element %q", label, el)
+				continue
+			}
+			checkFloat(t, label+" "+el, g.values[j], wantVal)
+		}
+	}
+}
+
+func checkVariances(t *testing.T, elements []string, group string, got variances, want map[string]float64) {
+	t.Helper()
+
+	label := "group " + group + " variance"
+	for i, el := range elements {
+		wantVal, ok := want[el]
+		if !ok {
+			t.Errorf("%s: expected.yaml has no value for element %q", label, el)
+			continue
+		}
+		checkFloat(t, label+" "+el, got.values[i], wantVal)
+	}
+}
+
+func checkFTest(t *testing.T, el string, got fTestResult, want expectedFTest) {
+	t.Helper()
+
+	label := "element " + el
+	if got.n1 != want.N1 {
+		t.Errorf("%s: n1 = %d, want %d", label, got.n1, want.N1)
+	}
+	if got.n2 != want.N2 {
+		t.Errorf("%s: n2 = %d, want %d", label, got.n2, want.N2)
+	}
+	if got.df1 != want.DF1 {
+		t.Errorf("%s: df1 = %d, want %d", label, got.df1, want.DF1)
+	}
+	if got.df2 != want.DF2 {
+		t.Errorf("%s: df2 = %d, want %d", label, got.df2, want.DF2)
+	}
+	checkFloat(t, label+" f", got.f, want.F)
+	checkFloat(t, label+" p_value", got.pValue, want.PValue)
+	checkFloat(t, label+" critical", got.critical, want.Critical)
+	if got.rejectH0 != want.RejectH0 {
+		t.Errorf("%s: rejectH0 = %t, want %t", label, got.rejectH0, want.RejectH0)
+	}
+}
+
+// checkFloat compares got against want with a tolerant comparator
+// (|got-want| <= atol + rtol*|want|) so golden values don't have to match
+// floating-point noise bit for bit.
+func checkFloat(t *testing.T, label string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > floatAtol+floatRtol*math.Abs(want) {
+		t.Errorf("%s: got %v, want %v", label, got, want)
+	}
+}
@@ -0,0 +1,237 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+)
+
+type (
+	// fTestResult holds a two-sample F-test for a single element: the
+	// variance ratio F = s1²/s2² (s1 always the larger variance, so
+	// F >= 1), its degrees of freedom, the two-tailed p-value and the
+	// two-tailed critical value F_{alpha/2, df1, df2} used to decide
+	// rejectH0, so the decision and the reported p-value agree on which
+	// tail they're testing.
+	fTestResult struct {
+		n1, n2   int
+		df1, df2 int
+		f        float64
+		pValue   float64
+		critical float64
+		rejectH0 bool
+	}
+
+	// fTestResults holds one fTestResult per element, aligned with
+	// Dataset.elements.
+	fTestResults []fTestResult
+)
+
+// element pairs an fTestResult with the name of the element it belongs to,
+// used to drive the per-element rendering of an F-test table.
+type element struct {
+	name   string
+	result fTestResult
+}
+
+// fTestElements pairs each of ds.fTests with the element name it belongs
+// to, in schema order.
+func (ds *Dataset) fTestElements() []element {
+	out := make([]element, len(ds.elements))
+	for i, name := range ds.elements {
+		out[i] = element{name: name, result: ds.fTests[i]}
+	}
+	return out
+}
+
+// CalculateFTests runs a two-sample F-test for every element, comparing the
+// mine and background variances calculated by CalculateVariances, and
+// rejects H0 (equal variances) when the observed F exceeds the critical
+// value at the given significance level.
+func (ds *Dataset) CalculateFTests(alpha float64) error {
+	n1 := len(ds.samples[groupTypeMine])
+	n2 := len(ds.samples[groupTypeBackground])
+	if n1 < 2 || n2 < 2 {
+		return fmt.Errorf("each group needs at least 2 samples to run an F-test, got mine=%d background=%d", n1, n2)
+	}
+
+	mine := ds.variances[groupTypeMine]
+	bg := ds.variances[groupTypeBackground]
+
+	results := make(fTestResults, len(ds.elements))
+	for i := range ds.elements {
+		results[i] = calcFTest(mine.values[i], bg.values[i], n1, n2, alpha)
+	}
+	ds.fTests = results
+	return nil
+}
+
+// calcFTest runs a two-sample F-test comparing v1 (n1 samples) against v2
+// (n2 samples), putting the larger variance on top so F >= 1.
+func calcFTest(v1, v2 float64, n1, n2 int, alpha float64) fTestResult {
+	numVar, denVar := v1, v2
+	dfNum, dfDen := n1-1, n2-1
+
+	if v2 > v1 {
+		numVar, denVar = v2, v1
+		dfNum, dfDen = n2-1, n1-1
+	}
+
+	f := numVar / denVar
+	p := fPValue(f, dfNum, dfDen)
+	critical := fCriticalValue(alpha/2, dfNum, dfDen)
+
+	return fTestResult{
+		n1:       n1,
+		n2:       n2,
+		df1:      dfNum,
+		df2:      dfDen,
+		f:        f,
+		pValue:   p,
+		critical: critical,
+		rejectH0: f > critical,
+	}
+}
+
+// fPValue returns the two-tailed p-value for an observed F ratio with df1
+// and df2 degrees of freedom, derived from the regularized incomplete beta
+// function: I_x(df2/2, df1/2), x = df2/(df2 + df1*F).
+func fPValue(f float64, df1, df2 int) float64 {
+	d1, d2 := float64(df1), float64(df2)
+	x := d2 / (d2 + d1*f)
+	oneTailed := betainc(x, d2/2, d1/2)
+	return 2 * math.Min(oneTailed, 1-oneTailed)
+}
+
+// fCriticalValue returns F_{alpha, df1, df2}, the F value whose upper-tail
+// probability equals alpha, found by Newton iteration (with a bisection
+// fallback to keep it safe) on the same incomplete beta used for fPValue.
+func fCriticalValue(alpha float64, df1, df2 int) float64 {
+	d1, d2 := float64(df1), float64(df2)
+	a, b := d2/2, d1/2
+	lnBeta := lgammaOf(a) + lgammaOf(b) - lgammaOf(a+b)
+
+	f := 1.0
+	lo, hi := 0.0, 1e7
+
+	for i := 0; i < 100; i++ {
+		x := d2 / (d2 + d1*f)
+		upperTail := betainc(x, a, b)
+
+		if upperTail > alpha {
+			lo = f
+		} else {
+			hi = f
+		}
+
+		logDensity := (a-1)*math.Log(x) + (b-1)*math.Log(1-x) - lnBeta
+		dxdF := -d1 * d2 / ((d2 + d1*f) * (d2 + d1*f))
+		dpdF := math.Exp(logDensity) * dxdF
+
+		next := f - (upperTail-alpha)/dpdF
+		if dpdF == 0 || next <= lo || next >= hi || math.IsNaN(next) {
+			next = (lo + hi) / 2
+		}
+
+		if math.Abs(next-f) < 1e-9 {
+			return next
+		}
+		f = next
+	}
+	return f
+}
+
+// betainc returns the regularized incomplete beta function I_x(a, b) using
+// the continued-fraction form of Lentz's method, avoiding a dependency on a
+// numerical library just for this.
+func betainc(x, a, b float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+
+	bt := math.Exp(lgammaOf(a+b) - lgammaOf(a) - lgammaOf(b) + a*math.Log(x) + b*math.Log(1-x))
+
+	if x < (a+1)/(a+b+2) {
+		return bt * betacf(a, b, x) / a
+	}
+	return 1 - bt*betacf(b, a, 1-x)/b
+}
+
+// betaQuantile returns x in [0, 1] such that betainc(x, a, b) = p, found by
+// bisection since betainc is monotonically increasing in x over [0, 1].
+func betaQuantile(p, a, b float64) float64 {
+	lo, hi := 0.0, 1.0
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if betainc(mid, a, b) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// betacf evaluates the continued fraction used by betainc via Lentz's
+// method.
+func betacf(a, b, x float64) float64 {
+	const (
+		maxIterations = 200
+		epsilon       = 3e-12
+		fpMin         = 1e-300
+	)
+
+	qab := a + b
+	qap := a + 1
+	qam := a - 1
+
+	c := 1.0
+	d := 1 - qab*x/qap
+	if math.Abs(d) < fpMin {
+		d = fpMin
+	}
+	d = 1 / d
+	h := d
+
+	for m := 1; m <= maxIterations; m++ {
+		m2 := float64(2 * m)
+
+		aa := float64(m) * (b - float64(m)) * x / ((qam + m2) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < fpMin {
+			d = fpMin
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < fpMin {
+			c = fpMin
+		}
+		d = 1 / d
+		h *= d * c
+
+		aa = -(a + float64(m)) * (qab + float64(m)) * x / ((a + m2) * (qap + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < fpMin {
+			d = fpMin
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < fpMin {
+			c = fpMin
+		}
+		d = 1 / d
+
+		del := d * c
+		h *= del
+
+		if math.Abs(del-1) < epsilon {
+			break
+		}
+	}
+	return h
+}
+
+func lgammaOf(x float64) float64 {
+	v, _ := math.Lgamma(x)
+	return v
+}
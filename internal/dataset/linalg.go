@@ -0,0 +1,261 @@
+package dataset
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// standardize returns a copy of data with each column centered on its mean
+// and scaled to unit variance, along with the means and standard
+// deviations used to do so. Columns with zero variance are left centered
+// but unscaled.
+func standardize(data [][]float64) (scaled [][]float64, means, stdDevs []float64) {
+	n := len(data)
+	if n == 0 {
+		return nil, nil, nil
+	}
+	p := len(data[0])
+
+	means = make([]float64, p)
+	for _, row := range data {
+		for j, v := range row {
+			means[j] += v
+		}
+	}
+	for j := range means {
+		means[j] /= float64(n)
+	}
+
+	stdDevs = make([]float64, p)
+	for _, row := range data {
+		for j, v := range row {
+			d := v - means[j]
+			stdDevs[j] += d * d
+		}
+	}
+	for j := range stdDevs {
+		stdDevs[j] = math.Sqrt(stdDevs[j] / float64(n-1))
+	}
+
+	scaled = make([][]float64, n)
+	for i, row := range data {
+		scaled[i] = make([]float64, p)
+		for j, v := range row {
+			if stdDevs[j] == 0 {
+				continue
+			}
+			scaled[i][j] = (v - means[j]) / stdDevs[j]
+		}
+	}
+	return scaled, means, stdDevs
+}
+
+func newMatrix(rows, cols int) [][]float64 {
+	m := make([][]float64, rows)
+	for i := range m {
+		m[i] = make([]float64, cols)
+	}
+	return m
+}
+
+func meanVector(data [][]float64) []float64 {
+	n := len(data)
+	if n == 0 {
+		return nil
+	}
+	p := len(data[0])
+
+	mean := make([]float64, p)
+	for _, row := range data {
+		for j, v := range row {
+			mean[j] += v
+		}
+	}
+	for j := range mean {
+		mean[j] /= float64(n)
+	}
+	return mean
+}
+
+// covarianceMatrix returns the sample covariance matrix (ddof=1) of data
+// around means.
+func covarianceMatrix(data [][]float64, means []float64) [][]float64 {
+	n := len(data)
+	p := len(means)
+	cov := newMatrix(p, p)
+	if n < 2 {
+		return cov
+	}
+
+	for i := 0; i < p; i++ {
+		for j := i; j < p; j++ {
+			var sum float64
+			for _, row := range data {
+				sum += (row[i] - means[i]) * (row[j] - means[j])
+			}
+			cov[i][j] = sum / float64(n-1)
+			cov[j][i] = cov[i][j]
+		}
+	}
+	return cov
+}
+
+// invertMatrix inverts a square matrix via Gauss-Jordan elimination with
+// partial pivoting.
+func invertMatrix(m [][]float64) ([][]float64, error) {
+	n := len(m)
+
+	aug := newMatrix(n, 2*n)
+	for i := 0; i < n; i++ {
+		copy(aug[i], m[i])
+		aug[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(aug[r][col]) > math.Abs(aug[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(aug[pivot][col]) < 1e-12 {
+			return nil, fmt.Errorf("matrix is singular or near-singular")
+		}
+		aug[col], aug[pivot] = aug[pivot], aug[col]
+
+		pivotVal := aug[col][col]
+		for c := 0; c < 2*n; c++ {
+			aug[col][c] /= pivotVal
+		}
+
+		for r := 0; r < n; r++ {
+			if r == col {
+				continue
+			}
+			factor := aug[r][col]
+			for c := 0; c < 2*n; c++ {
+				aug[r][c] -= factor * aug[col][c]
+			}
+		}
+	}
+
+	inv := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		copy(inv[i], aug[i][n:])
+	}
+	return inv, nil
+}
+
+// mahalanobisDistanceSquared computes (x-mean)^T covInv (x-mean).
+func mahalanobisDistanceSquared(x, mean []float64, covInv [][]float64) float64 {
+	p := len(x)
+	diff := make([]float64, p)
+	for i := range diff {
+		diff[i] = x[i] - mean[i]
+	}
+
+	var d float64
+	for i := 0; i < p; i++ {
+		var rowSum float64
+		for j := 0; j < p; j++ {
+			rowSum += covInv[i][j] * diff[j]
+		}
+		d += diff[i] * rowSum
+	}
+	return d
+}
+
+// jacobiEigen computes the eigenvalues and eigenvectors of a symmetric
+// matrix using the classic cyclic Jacobi rotation method. Eigenvectors are
+// returned as columns of the result, sorted by decreasing eigenvalue.
+func jacobiEigen(a [][]float64) (eigenvalues []float64, eigenvectors [][]float64) {
+	n := len(a)
+
+	m := newMatrix(n, n)
+	for i := range a {
+		copy(m[i], a[i])
+	}
+
+	v := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		v[i][i] = 1
+	}
+
+	const maxSweeps = 100
+	for sweep := 0; sweep < maxSweeps; sweep++ {
+		var off float64
+		for i := 0; i < n; i++ {
+			for j := i + 1; j < n; j++ {
+				off += m[i][j] * m[i][j]
+			}
+		}
+		if off < 1e-14 {
+			break
+		}
+
+		for p := 0; p < n; p++ {
+			for q := p + 1; q < n; q++ {
+				if math.Abs(m[p][q]) < 1e-300 {
+					continue
+				}
+
+				theta := (m[q][q] - m[p][p]) / (2 * m[p][q])
+				var t float64
+				if theta == 0 {
+					t = 1
+				} else {
+					t = math.Copysign(1, theta) / (math.Abs(theta) + math.Sqrt(theta*theta+1))
+				}
+				c := 1 / math.Sqrt(t*t+1)
+				s := t * c
+
+				mpp, mqq, mpq := m[p][p], m[q][q], m[p][q]
+				m[p][p] = c*c*mpp - 2*s*c*mpq + s*s*mqq
+				m[q][q] = s*s*mpp + 2*s*c*mpq + c*c*mqq
+				m[p][q] = 0
+				m[q][p] = 0
+
+				for i := 0; i < n; i++ {
+					if i != p && i != q {
+						mip, miq := m[i][p], m[i][q]
+						m[i][p] = c*mip - s*miq
+						m[p][i] = m[i][p]
+						m[i][q] = s*mip + c*miq
+						m[q][i] = m[i][q]
+					}
+				}
+
+				for i := 0; i < n; i++ {
+					vip, viq := v[i][p], v[i][q]
+					v[i][p] = c*vip - s*viq
+					v[i][q] = s*vip + c*viq
+				}
+			}
+		}
+	}
+
+	eigenvalues = make([]float64, n)
+	for i := 0; i < n; i++ {
+		eigenvalues[i] = m[i][i]
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		return eigenvalues[order[i]] > eigenvalues[order[j]]
+	})
+
+	sortedValues := make([]float64, n)
+	sortedVectors := newMatrix(n, n)
+	for newIdx, oldIdx := range order {
+		sortedValues[newIdx] = eigenvalues[oldIdx]
+		for i := 0; i < n; i++ {
+			sortedVectors[i][newIdx] = v[i][oldIdx]
+		}
+	}
+
+	return sortedValues, sortedVectors
+}
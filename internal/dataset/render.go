@@ -0,0 +1,511 @@
+package dataset
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/jedib0t/go-pretty/table"
+)
+
+// OutputFormat selects which Renderer implementation NewRenderer returns.
+type OutputFormat string
+
+const (
+	OutputFormatTable OutputFormat = "table"
+	OutputFormatJSON  OutputFormat = "json"
+	OutputFormatCSV   OutputFormat = "csv"
+	OutputFormatTSV   OutputFormat = "tsv"
+)
+
+var tableStyle table.Style = table.Style{
+	Name:    "StyleRounded",
+	Box:     table.StyleBoxRounded,
+	Color:   table.ColorOptionsDefault,
+	Format:  table.FormatOptionsDefault,
+	Options: table.OptionsDefault,
+	Title:   table.TitleOptionsDefault,
+}
+
+// Renderer turns dataset results into a printable string. Implementations
+// exist for human-readable tables as well as machine-readable formats so
+// geogrinch output can be consumed by other tools in a pipeline.
+type Renderer interface {
+	RenderDataset(ds *Dataset) (string, error)
+	RenderVariances(ds *Dataset) (string, error)
+	RenderFTest(ds *Dataset) (string, error)
+	RenderOutliers(ds *Dataset) (string, error)
+	RenderPCA(ds *Dataset) (string, error)
+}
+
+// NewRenderer returns the Renderer for the given output format. An empty
+// format falls back to the table renderer.
+func NewRenderer(format OutputFormat, precision int) (Renderer, error) {
+	switch format {
+	case OutputFormatTable, "":
+		return tableRenderer{precision: precision}, nil
+	case OutputFormatJSON:
+		return jsonRenderer{precision: precision}, nil
+	case OutputFormatCSV:
+		return delimitedRenderer{precision: precision, comma: ','}, nil
+	case OutputFormatTSV:
+		return delimitedRenderer{precision: precision, comma: '\t'}, nil
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", format)
+	}
+}
+
+type tableRenderer struct {
+	precision int
+}
+
+func (r tableRenderer) RenderDataset(ds *Dataset) (string, error) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle)
+	tw.SetTitle("DATASET")
+
+	header := table.Row{"group", "sample", "x_utm", "y_utm"}
+	for _, el := range ds.elements {
+		header = append(header, el)
+	}
+	tw.AppendHeader(header)
+
+	for _, group := range groupTypes {
+		for _, s := range ds.samples[group] {
+			row := table.Row{s.group, s.code, s.xUtm, s.yUtm}
+			for _, v := range s.values {
+				row = append(row, r.format(v))
+			}
+			tw.AppendRow(row)
+		}
+	}
+	return tw.Render(), nil
+}
+
+func (r tableRenderer) RenderVariances(ds *Dataset) (string, error) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle)
+	tw.SetTitle("VARIANCE")
+
+	header := table.Row{"group"}
+	for _, el := range ds.elements {
+		header = append(header, el)
+	}
+	tw.AppendHeader(header)
+
+	for _, group := range groupTypes {
+		vari := ds.variances[group]
+		row := table.Row{group}
+		for _, v := range vari.values {
+			row = append(row, r.format(v))
+		}
+		tw.AppendRow(row)
+	}
+	return tw.Render(), nil
+}
+
+func (r tableRenderer) RenderFTest(ds *Dataset) (string, error) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle)
+	tw.SetTitle("F-TEST")
+	tw.AppendHeader(table.Row{"element", "n1", "n2", "df1", "df2", "F", "p_value", "critical", "significant"})
+
+	for _, el := range ds.fTestElements() {
+		tw.AppendRow(table.Row{
+			el.name,
+			el.result.n1,
+			el.result.n2,
+			el.result.df1,
+			el.result.df2,
+			r.format(el.result.f),
+			r.format(el.result.pValue),
+			r.format(el.result.critical),
+			yesNo(el.result.rejectH0),
+		})
+	}
+	return tw.Render(), nil
+}
+
+func (r tableRenderer) RenderOutliers(ds *Dataset) (string, error) {
+	tw := table.NewWriter()
+	tw.SetStyle(tableStyle)
+	tw.SetTitle("OUTLIERS")
+	tw.AppendHeader(table.Row{"sample", "group", "distance", "cutoff", "outlier"})
+
+	for _, o := range ds.outliers {
+		tw.AppendRow(table.Row{
+			o.code,
+			o.group,
+			r.format(o.distance),
+			r.format(o.cutoff),
+			yesNo(o.isOutlier),
+		})
+	}
+	return tw.Render(), nil
+}
+
+func (r tableRenderer) RenderPCA(ds *Dataset) (string, error) {
+	loadings := table.NewWriter()
+	loadings.SetStyle(tableStyle)
+	loadings.SetTitle("PCA LOADINGS")
+	loadings.AppendHeader(table.Row{"element", "pc1", "pc2"})
+	for _, l := range ds.pca.loadings {
+		loadings.AppendRow(table.Row{l.element, r.format(l.pc1), r.format(l.pc2)})
+	}
+
+	variance := table.NewWriter()
+	variance.SetStyle(tableStyle)
+	variance.SetTitle("PCA EXPLAINED VARIANCE RATIO")
+	header := make(table.Row, len(ds.pca.explainedVarianceRatio))
+	row := make(table.Row, len(ds.pca.explainedVarianceRatio))
+	for i, ratio := range ds.pca.explainedVarianceRatio {
+		header[i] = fmt.Sprintf("pc%d", i+1)
+		row[i] = r.format(ratio)
+	}
+	variance.AppendHeader(header)
+	variance.AppendRow(row)
+
+	scores := table.NewWriter()
+	scores.SetStyle(tableStyle)
+	scores.SetTitle("PCA SCORES")
+	scores.AppendHeader(table.Row{"sample", "group", "pc1", "pc2"})
+	for _, s := range ds.pca.scores {
+		scores.AppendRow(table.Row{s.code, s.group, r.format(s.pc1), r.format(s.pc2)})
+	}
+
+	return loadings.Render() + "\n" + variance.Render() + "\n" + scores.Render(), nil
+}
+
+func (r tableRenderer) format(v float64) string {
+	return fmt.Sprintf("%.*f", r.precision, v)
+}
+
+type jsonRenderer struct {
+	precision int
+}
+
+func (r jsonRenderer) RenderDataset(ds *Dataset) (string, error) {
+	type row struct {
+		Group  string             `json:"group"`
+		Code   string             `json:"sample"`
+		XUtm   string             `json:"x_utm"`
+		YUtm   string             `json:"y_utm"`
+		Values map[string]float64 `json:"values"`
+	}
+
+	var rows []row
+	for _, group := range groupTypes {
+		for _, s := range ds.samples[group] {
+			rows = append(rows, row{
+				Group:  string(s.group),
+				Code:   s.code,
+				XUtm:   s.xUtm,
+				YUtm:   s.yUtm,
+				Values: valuesMap(ds.elements, s.values, r.precision),
+			})
+		}
+	}
+	return marshalJSON(rows)
+}
+
+func (r jsonRenderer) RenderVariances(ds *Dataset) (string, error) {
+	type row struct {
+		Group  string             `json:"group"`
+		Values map[string]float64 `json:"values"`
+	}
+
+	var rows []row
+	for _, group := range groupTypes {
+		vari := ds.variances[group]
+		rows = append(rows, row{
+			Group:  string(group),
+			Values: valuesMap(ds.elements, vari.values, r.precision),
+		})
+	}
+	return marshalJSON(rows)
+}
+
+// valuesMap pairs elements with values, rounded to precision, keyed by
+// element name so renderers don't need a fixed schema of struct fields.
+func valuesMap(elements []string, values []float64, precision int) map[string]float64 {
+	m := make(map[string]float64, len(elements))
+	for i, el := range elements {
+		m[el] = round(values[i], precision)
+	}
+	return m
+}
+
+func (r jsonRenderer) RenderFTest(ds *Dataset) (string, error) {
+	type row struct {
+		Element     string  `json:"element"`
+		N1          int     `json:"n1"`
+		N2          int     `json:"n2"`
+		DF1         int     `json:"df1"`
+		DF2         int     `json:"df2"`
+		F           float64 `json:"f"`
+		PValue      float64 `json:"p_value"`
+		Critical    float64 `json:"critical"`
+		Significant bool    `json:"significant"`
+	}
+
+	var rows []row
+	for _, el := range ds.fTestElements() {
+		rows = append(rows, row{
+			Element:     el.name,
+			N1:          el.result.n1,
+			N2:          el.result.n2,
+			DF1:         el.result.df1,
+			DF2:         el.result.df2,
+			F:           round(el.result.f, r.precision),
+			PValue:      round(el.result.pValue, r.precision),
+			Critical:    round(el.result.critical, r.precision),
+			Significant: el.result.rejectH0,
+		})
+	}
+	return marshalJSON(rows)
+}
+
+func (r jsonRenderer) RenderOutliers(ds *Dataset) (string, error) {
+	type row struct {
+		Sample    string  `json:"sample"`
+		Group     string  `json:"group"`
+		Distance  float64 `json:"distance"`
+		Cutoff    float64 `json:"cutoff"`
+		IsOutlier bool    `json:"outlier"`
+	}
+
+	rows := make([]row, len(ds.outliers))
+	for i, o := range ds.outliers {
+		rows[i] = row{
+			Sample:    o.code,
+			Group:     string(o.group),
+			Distance:  round(o.distance, r.precision),
+			Cutoff:    round(o.cutoff, r.precision),
+			IsOutlier: o.isOutlier,
+		}
+	}
+	return marshalJSON(rows)
+}
+
+func (r jsonRenderer) RenderPCA(ds *Dataset) (string, error) {
+	type loading struct {
+		Element string  `json:"element"`
+		PC1     float64 `json:"pc1"`
+		PC2     float64 `json:"pc2"`
+	}
+
+	type score struct {
+		Sample string  `json:"sample"`
+		Group  string  `json:"group"`
+		PC1    float64 `json:"pc1"`
+		PC2    float64 `json:"pc2"`
+	}
+
+	loadings := make([]loading, len(ds.pca.loadings))
+	for i, l := range ds.pca.loadings {
+		loadings[i] = loading{
+			Element: l.element,
+			PC1:     round(l.pc1, r.precision),
+			PC2:     round(l.pc2, r.precision),
+		}
+	}
+
+	explainedVarianceRatio := make([]float64, len(ds.pca.explainedVarianceRatio))
+	for i, ratio := range ds.pca.explainedVarianceRatio {
+		explainedVarianceRatio[i] = round(ratio, r.precision)
+	}
+
+	scores := make([]score, len(ds.pca.scores))
+	for i, s := range ds.pca.scores {
+		scores[i] = score{
+			Sample: s.code,
+			Group:  string(s.group),
+			PC1:    round(s.pc1, r.precision),
+			PC2:    round(s.pc2, r.precision),
+		}
+	}
+
+	return marshalJSON(struct {
+		Loadings               []loading `json:"loadings"`
+		ExplainedVarianceRatio []float64 `json:"explained_variance_ratio"`
+		Scores                 []score   `json:"scores"`
+	}{
+		Loadings:               loadings,
+		ExplainedVarianceRatio: explainedVarianceRatio,
+		Scores:                 scores,
+	})
+}
+
+func marshalJSON(v interface{}) (string, error) {
+	out, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("could not marshal to JSON: %s", err)
+	}
+	return string(out), nil
+}
+
+// delimitedRenderer renders CSV/TSV output, selected by comma.
+type delimitedRenderer struct {
+	precision int
+	comma     rune
+}
+
+func (r delimitedRenderer) RenderDataset(ds *Dataset) (string, error) {
+	header := append([]string{"group", "sample", "x_utm", "y_utm"}, ds.elements...)
+	return r.render(
+		header,
+		func(w *csv.Writer) error {
+			for _, group := range groupTypes {
+				for _, s := range ds.samples[group] {
+					row := []string{string(s.group), s.code, s.xUtm, s.yUtm}
+					for _, v := range s.values {
+						row = append(row, r.format(v))
+					}
+					if err := w.Write(row); err != nil {
+						return err
+					}
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (r delimitedRenderer) RenderVariances(ds *Dataset) (string, error) {
+	header := append([]string{"group"}, ds.elements...)
+	return r.render(
+		header,
+		func(w *csv.Writer) error {
+			for _, group := range groupTypes {
+				vari := ds.variances[group]
+				row := []string{string(group)}
+				for _, v := range vari.values {
+					row = append(row, r.format(v))
+				}
+				if err := w.Write(row); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (r delimitedRenderer) RenderFTest(ds *Dataset) (string, error) {
+	return r.render(
+		[]string{"element", "n1", "n2", "df1", "df2", "F", "p_value", "critical", "significant"},
+		func(w *csv.Writer) error {
+			for _, el := range ds.fTestElements() {
+				if err := w.Write([]string{
+					el.name,
+					strconv.Itoa(el.result.n1),
+					strconv.Itoa(el.result.n2),
+					strconv.Itoa(el.result.df1),
+					strconv.Itoa(el.result.df2),
+					r.format(el.result.f),
+					r.format(el.result.pValue),
+					r.format(el.result.critical),
+					yesNo(el.result.rejectH0),
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (r delimitedRenderer) RenderOutliers(ds *Dataset) (string, error) {
+	return r.render(
+		[]string{"sample", "group", "distance", "cutoff", "outlier"},
+		func(w *csv.Writer) error {
+			for _, o := range ds.outliers {
+				if err := w.Write([]string{
+					o.code,
+					string(o.group),
+					r.format(o.distance),
+					r.format(o.cutoff),
+					yesNo(o.isOutlier),
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+// RenderPCA renders only the per-sample scores: CSV/TSV output is meant for
+// piping into other tools, and the scores are what those tools plot.
+func (r delimitedRenderer) RenderPCA(ds *Dataset) (string, error) {
+	return renderPCAScoresCSV(ds, r.precision, r.comma)
+}
+
+// renderPCAScoresCSV renders per-sample PC1/PC2 scores, used both by the
+// CSV/TSV Renderer and by ExportPCAScores, which always writes CSV
+// regardless of the active --output-format.
+func renderPCAScoresCSV(ds *Dataset, precision int, comma rune) (string, error) {
+	r := delimitedRenderer{precision: precision, comma: comma}
+	return r.render(
+		[]string{"sample", "group", "pc1", "pc2"},
+		func(w *csv.Writer) error {
+			for _, s := range ds.pca.scores {
+				if err := w.Write([]string{
+					s.code,
+					string(s.group),
+					r.format(s.pc1),
+					r.format(s.pc2),
+				}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	)
+}
+
+func (r delimitedRenderer) render(header []string, writeRows func(*csv.Writer) error) (string, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Comma = r.comma
+
+	if err := w.Write(header); err != nil {
+		return "", fmt.Errorf("could not write header: %s", err)
+	}
+	if err := writeRows(w); err != nil {
+		return "", fmt.Errorf("could not write rows: %s", err)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return "", fmt.Errorf("could not flush output: %s", err)
+	}
+	return buf.String(), nil
+}
+
+func (r delimitedRenderer) format(v float64) string {
+	return fmt.Sprintf("%.*f", r.precision, v)
+}
+
+func round(v float64, precision int) float64 {
+	var pow float64 = 1
+	for i := 0; i < precision; i++ {
+		pow *= 10
+	}
+	return float64(int64(v*pow+sign(v)*0.5)) / pow
+}
+
+func sign(v float64) float64 {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}